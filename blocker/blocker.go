@@ -0,0 +1,55 @@
+// Package blocker defines the pluggable firewall backend interface. The
+// RouterOS implementation is the only backend today.
+package blocker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Blocker adds an address to a firewall address list with the given
+// timeout ("0" means permanent).
+type Blocker interface {
+	Block(ctx context.Context, list, address, timeout string) error
+	ListAddresses(ctx context.Context, list string) ([]string, error)
+	Close() error
+}
+
+// GetTimeout returns the RouterOS timeout string for the Nth hit against an
+// escalation table expressed in hours. Once count exceeds the table, "0"
+// (permanent) is returned.
+func GetTimeout(count int, hours []int) string {
+	if count <= len(hours) {
+		return fmt.Sprintf("%02d:00:00", hours[count-1])
+	}
+	return "0"
+}
+
+// ParseTimeout converts a timeout string produced by GetTimeout back into
+// a duration, so callers can compute an expiry aligned with what was
+// pushed to RouterOS. ok is false for "0" (permanent, never expires).
+func ParseTimeout(timeout string) (d time.Duration, ok bool) {
+	if timeout == "0" {
+		return 0, false
+	}
+	parts := strings.Split(timeout, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, true
+}
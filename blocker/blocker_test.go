@@ -0,0 +1,55 @@
+package blocker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     string
+		wantD  time.Duration
+		wantOK bool
+	}{
+		{"permanent", "0", 0, false},
+		{"one hour", "01:00:00", time.Hour, true},
+		{"168 hours", "168:00:00", 168 * time.Hour, true},
+		{"minutes and seconds", "00:05:30", 5*time.Minute + 30*time.Second, true},
+		{"too few segments", "1:2", 0, false},
+		{"too many segments", "1:2:3:4", 0, false},
+		{"non-numeric hours", "ab:00:00", 0, false},
+		{"non-numeric minutes", "01:ab:00", 0, false},
+		{"non-numeric seconds", "01:00:ab", 0, false},
+		{"empty string", "", 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, ok := ParseTimeout(tc.in)
+			if ok != tc.wantOK || d != tc.wantD {
+				t.Fatalf("ParseTimeout(%q) = (%v, %v), want (%v, %v)", tc.in, d, ok, tc.wantD, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetTimeout(t *testing.T) {
+	hours := []int{1, 3, 7}
+
+	cases := []struct {
+		count int
+		want  string
+	}{
+		{1, "01:00:00"},
+		{2, "03:00:00"},
+		{3, "07:00:00"},
+		{4, "0"},
+	}
+
+	for _, tc := range cases {
+		if got := GetTimeout(tc.count, hours); got != tc.want {
+			t.Fatalf("GetTimeout(%d, %v) = %q, want %q", tc.count, hours, got, tc.want)
+		}
+	}
+}
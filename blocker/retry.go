@@ -0,0 +1,31 @@
+package blocker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// WithRetry calls fn up to attempts times, doubling the delay between
+// tries starting at base. It exists because RunArgs errors against a
+// flaky RouterOS session were previously just logged and dropped.
+func WithRetry(ctx context.Context, attempts int, base time.Duration, fn func() error) error {
+	var err error
+	delay := base
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+		log.Printf("⚠️ attempt %d/%d failed: %v, retrying in %s", attempt, attempts, err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package blocker
+
+import (
+	"context"
+
+	"github.com/go-routeros/routeros"
+)
+
+// RouterOSBlocker drives a MikroTik RouterOS device over its API.
+type RouterOSBlocker struct {
+	client *routeros.Client
+}
+
+// Dial connects to a RouterOS device and authenticates.
+func Dial(address, user, pass string) (*RouterOSBlocker, error) {
+	client, err := routeros.Dial(address, user, pass)
+	if err != nil {
+		return nil, err
+	}
+	return &RouterOSBlocker{client: client}, nil
+}
+
+// Block adds address to list with the given timeout via
+// /ip/firewall/address-list/add. ctx is accepted for interface
+// compatibility; the underlying client call is not context-aware.
+func (b *RouterOSBlocker) Block(ctx context.Context, list, address, timeout string) error {
+	_, err := b.client.RunArgs([]string{
+		"/ip/firewall/address-list/add",
+		"=list=" + list,
+		"=address=" + address,
+		"=timeout=" + timeout,
+	})
+	return err
+}
+
+// ListAddresses returns every address currently in list.
+func (b *RouterOSBlocker) ListAddresses(ctx context.Context, list string) ([]string, error) {
+	reply, err := b.client.RunArgs([]string{
+		"/ip/firewall/address-list/print",
+		"?list=" + list,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]string, 0, len(reply.Re))
+	for _, re := range reply.Re {
+		if a, ok := re.Map["address"]; ok {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs, nil
+}
+
+// Close closes the underlying RouterOS API connection.
+func (b *RouterOSBlocker) Close() error {
+	b.client.Close()
+	return nil
+}
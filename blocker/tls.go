@@ -0,0 +1,89 @@
+package blocker
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-routeros/routeros"
+)
+
+// TLSConfig configures an API-SSL connection to RouterOS (port 8729 by
+// convention, though the address string still carries the port).
+type TLSConfig struct {
+	Enabled  bool
+	CAFile   string // PEM CA bundle; ignored if PinnedSHA256 is set
+	CertFile string // client certificate, for mutual TLS
+	KeyFile  string
+
+	// PinnedSHA256 is a list of hex SHA-256 fingerprints of acceptable
+	// leaf certificates. When set, normal chain-of-trust verification is
+	// skipped in favor of pinned trust (nebula/tailscale-style): the
+	// server's certificate must match one of these fingerprints exactly.
+	PinnedSHA256 []string
+}
+
+// DialTLS connects to a RouterOS device over API-SSL and authenticates.
+func DialTLS(address, user, pass string, t TLSConfig) (*RouterOSBlocker, error) {
+	tlsConfig, err := buildTLSConfig(t)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := routeros.DialTLS(address, user, pass, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &RouterOSBlocker{client: client}, nil
+}
+
+func buildTLSConfig(t TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(t.PinnedSHA256) > 0 {
+		pins := make(map[string]bool, len(t.PinnedSHA256))
+		for _, p := range t.PinnedSHA256 {
+			pins[strings.ToLower(strings.ReplaceAll(p, ":", ""))] = true
+		}
+		// System/CA verification is meaningless once we're pinning a
+		// specific certificate, so skip it and verify the fingerprint
+		// ourselves instead.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("tls: server presented no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if pins[fmt.Sprintf("%x", sum)] {
+				return nil
+			}
+			return fmt.Errorf("tls: leaf certificate does not match a pinned fingerprint")
+		}
+		return tlsConfig, nil
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_file %s contains no usable certificates", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,105 @@
+package blocker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func selfSignedCert(t *testing.T, cn string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	return der
+}
+
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return fmt.Sprintf("%x", sum)
+}
+
+func TestBuildTLSConfig_PinnedCertAccepted(t *testing.T) {
+	pinned := selfSignedCert(t, "pinned")
+
+	tlsConfig, err := buildTLSConfig(TLSConfig{PinnedSHA256: []string{fingerprint(pinned)}})
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Fatal("InsecureSkipVerify must be true when pins are configured")
+	}
+
+	if err := tlsConfig.VerifyPeerCertificate([][]byte{pinned}, nil); err != nil {
+		t.Fatalf("VerifyPeerCertificate() = %v, want nil for a pinned cert", err)
+	}
+}
+
+func TestBuildTLSConfig_UnpinnedCertRejected(t *testing.T) {
+	pinned := selfSignedCert(t, "pinned")
+	other := selfSignedCert(t, "other")
+
+	tlsConfig, err := buildTLSConfig(TLSConfig{PinnedSHA256: []string{fingerprint(pinned)}})
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+
+	if err := tlsConfig.VerifyPeerCertificate([][]byte{other}, nil); err == nil {
+		t.Fatal("VerifyPeerCertificate() = nil, want an error for a non-pinned cert")
+	}
+}
+
+func TestBuildTLSConfig_PinsAcceptColonSeparatedFingerprints(t *testing.T) {
+	pinned := selfSignedCert(t, "pinned")
+	raw := fingerprint(pinned)
+	var colons string
+	for i := 0; i < len(raw); i += 2 {
+		if colons != "" {
+			colons += ":"
+		}
+		colons += raw[i : i+2]
+	}
+
+	tlsConfig, err := buildTLSConfig(TLSConfig{PinnedSHA256: []string{colons}})
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+
+	if err := tlsConfig.VerifyPeerCertificate([][]byte{pinned}, nil); err != nil {
+		t.Fatalf("VerifyPeerCertificate() = %v, want nil for a colon-separated pin", err)
+	}
+}
+
+func TestBuildTLSConfig_NoPinsLeavesVerificationEnabled(t *testing.T) {
+	tlsConfig, err := buildTLSConfig(TLSConfig{})
+	if err != nil {
+		t.Fatalf("buildTLSConfig: %v", err)
+	}
+	if tlsConfig.InsecureSkipVerify {
+		t.Fatal("InsecureSkipVerify must stay false when no pins are configured")
+	}
+	if tlsConfig.VerifyPeerCertificate != nil {
+		t.Fatal("VerifyPeerCertificate must be nil when no pins are configured")
+	}
+}
@@ -0,0 +1,402 @@
+// Package config loads the blocker's YAML config file and turns it into
+// the structures the rest of the library works with.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFeedInterval is how often a feed is polled in daemon mode when no
+// feed-level interval override is set.
+const DefaultFeedInterval = 5 * time.Minute
+
+// DefaultHTTPAddr is where the daemon's /healthz and /metrics endpoints
+// listen when http_addr is unset.
+const DefaultHTTPAddr = ":9090"
+
+// Defaults for subnet aggregation, used when the aggregation block (or
+// individual fields within it) is omitted.
+const (
+	DefaultAggMaskV4    = 24
+	DefaultAggMaskV6    = 64
+	DefaultAggThreshold = 5
+)
+
+// Defaults for the reaper, used when the reaper block (or individual
+// fields within it) is omitted.
+const (
+	DefaultReapInterval   = 1 * time.Hour
+	DefaultDecayAfterDays = 14
+)
+
+// FeedConfig describes one configured feed ingester. Not every field
+// applies to every Type.
+type FeedConfig struct {
+	Type          string
+	Path          string
+	Column        int
+	APIKey        string
+	Threshold     int
+	CollectionURL string
+	Username      string
+	Password      string
+	Interval      time.Duration
+}
+
+// TLSConfig configures an API-SSL connection to RouterOS. See
+// blocker.TLSConfig, which mirrors this shape, for the pinning semantics.
+type TLSConfig struct {
+	Enabled      bool
+	CAFile       string
+	CertFile     string
+	KeyFile      string
+	PinnedSHA256 []string
+}
+
+// Config is the parsed, defaulted, and validated contents of config.yaml.
+type Config struct {
+	MtHost     string
+	MtUser     string
+	MtPass     string
+	MtTLS      TLSConfig
+	ListTemp   string
+	ListPerm   string
+	Whitelist  []string
+	StateFile  string
+	Escalation []int
+	Feeds      []FeedConfig
+	HTTPAddr   string
+
+	// EscalationRange is the hours-per-hit table used once a subnet has
+	// been aggregated into a single range block, separate from the
+	// per-host Escalation table.
+	EscalationRange []int
+	AggMaskV4       int
+	AggMaskV6       int
+	AggThreshold    int
+
+	// ReapInterval is how often the daemon decays/expires stale state
+	// records; DecayAfter is how long a host or subnet must go unseen
+	// before it starts decaying.
+	ReapInterval time.Duration
+	DecayAfter   time.Duration
+}
+
+// yamlFeed is the on-disk shape of one feeds[] entry.
+type yamlFeed struct {
+	Type          string `yaml:"type"`
+	Path          string `yaml:"path,omitempty"`
+	Column        int    `yaml:"column,omitempty"`
+	APIKey        string `yaml:"api_key,omitempty"`
+	Threshold     int    `yaml:"threshold,omitempty"`
+	CollectionURL string `yaml:"collection_url,omitempty"`
+	Username      string `yaml:"username,omitempty"`
+	Password      string `yaml:"password,omitempty"`
+	Interval      string `yaml:"interval,omitempty"`
+}
+
+// yamlConfig is the on-disk shape of config.yaml.
+type yamlConfig struct {
+	Mikrotik struct {
+		Host string `yaml:"host"`
+		User string `yaml:"user"`
+		Pass string `yaml:"pass"`
+		TLS  struct {
+			Enabled      bool     `yaml:"enabled,omitempty"`
+			CAFile       string   `yaml:"ca_file,omitempty"`
+			CertFile     string   `yaml:"cert_file,omitempty"`
+			KeyFile      string   `yaml:"key_file,omitempty"`
+			PinnedSHA256 []string `yaml:"pinned_sha256,omitempty"`
+		} `yaml:"tls,omitempty"`
+	} `yaml:"mikrotik"`
+
+	Lists struct {
+		Temp string `yaml:"temp"`
+		Perm string `yaml:"perm"`
+	} `yaml:"lists"`
+
+	Whitelist []string `yaml:"whitelist"`
+	StateFile string   `yaml:"state_file"`
+	HTTPAddr  string   `yaml:"http_addr,omitempty"`
+
+	Escalation      []int `yaml:"escalation"`
+	EscalationRange []int `yaml:"escalation_range,omitempty"`
+
+	Aggregation struct {
+		MaskV4    int `yaml:"mask_v4,omitempty"`
+		MaskV6    int `yaml:"mask_v6,omitempty"`
+		Threshold int `yaml:"threshold,omitempty"`
+	} `yaml:"aggregation,omitempty"`
+
+	Reaper struct {
+		Interval       string `yaml:"interval,omitempty"`
+		DecayAfterDays int    `yaml:"decay_after_days,omitempty"`
+	} `yaml:"reaper,omitempty"`
+
+	Feeds []yamlFeed `yaml:"feeds"`
+}
+
+// DefaultYAML is what GenerateDefault writes: every key present, with
+// comments explaining it, so an operator can uncomment what they need.
+const DefaultYAML = `# MikroTik RouterOS connection
+mikrotik:
+  host: 192.168.88.1:8728
+  user: admin
+  pass: yourpassword
+  # API-SSL (port 8729). With pinned_sha256 set, the certificate is
+  # trusted by fingerprint instead of a CA chain; ca_file is ignored.
+  # tls:
+  #   enabled: true
+  #   ca_file: ""
+  #   cert_file: ""
+  #   key_file: ""
+  #   pinned_sha256: []
+
+# Address-list names
+lists:
+  temp: blocked_attackers
+  perm: blocked_permanent
+
+# Bare IPs or CIDRs that are never blocked
+whitelist:
+  - 8.8.8.8
+  - 192.168.1.0/24
+
+state_file: /opt/htb_blocker/state.db
+
+# /healthz + /metrics listen address (daemon mode only)
+http_addr: ":9090"
+
+# Hours-per-hit escalation table for individual hosts. Once a host's hit
+# count exceeds the table length, it is blocked permanently.
+escalation: [1, 3, 7]
+
+# Hours-per-hit escalation table used once a subnet has been aggregated
+# into a single range block (see aggregation below).
+escalation_range: [1, 3, 7]
+
+# Escalate a whole subnet once "threshold" hits have accumulated from hosts
+# within it (repeat hits on one host count too), instead of tracking each
+# host's /32 or /128 separately.
+aggregation:
+  mask_v4: 24
+  mask_v6: 64
+  threshold: 5
+
+# The daemon periodically decays escalation counts for hosts/subnets not
+# seen in decay_after_days, de-escalating (and eventually forgetting)
+# attackers who stopped. interval controls how often the pass runs.
+reaper:
+  interval: 1h
+  decay_after_days: 14
+
+# Feeds to poll. "type" selects the ingester; remaining fields are
+# type-specific. "interval" (e.g. "5m", "1h") only matters in daemon mode.
+feeds:
+  - type: csv
+    path: /opt/htb_blocker/attackers.csv
+    column: 0
+    interval: 5m
+  # - type: abuseipdb
+  #   api_key: ""
+  #   threshold: 90
+  #   interval: 5m
+  # - type: otx
+  #   api_key: ""
+  #   interval: 15m
+  # - type: spamhaus
+  #   interval: 1h
+  # - type: stix
+  #   collection_url: ""
+  #   username: ""
+  #   password: ""
+  #   interval: 5m
+`
+
+// GenerateDefault returns the fully-commented default config.yaml contents.
+func GenerateDefault() string {
+	return DefaultYAML
+}
+
+// Load reads, parses, defaults, and validates the YAML config at path.
+func Load(path string) (*Config, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// Parse decodes raw YAML bytes into a validated Config. Unknown fields are
+// rejected so typos surface immediately instead of being silently dropped.
+func Parse(raw []byte) (*Config, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+
+	var y yamlConfig
+	if err := dec.Decode(&y); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	cfg := &Config{
+		MtHost: y.Mikrotik.Host,
+		MtUser: y.Mikrotik.User,
+		MtPass: y.Mikrotik.Pass,
+		MtTLS: TLSConfig{
+			Enabled:      y.Mikrotik.TLS.Enabled,
+			CAFile:       y.Mikrotik.TLS.CAFile,
+			CertFile:     y.Mikrotik.TLS.CertFile,
+			KeyFile:      y.Mikrotik.TLS.KeyFile,
+			PinnedSHA256: y.Mikrotik.TLS.PinnedSHA256,
+		},
+		ListTemp:        y.Lists.Temp,
+		ListPerm:        y.Lists.Perm,
+		Whitelist:       y.Whitelist,
+		StateFile:       y.StateFile,
+		HTTPAddr:        y.HTTPAddr,
+		Escalation:      y.Escalation,
+		EscalationRange: y.EscalationRange,
+		AggMaskV4:       y.Aggregation.MaskV4,
+		AggMaskV6:       y.Aggregation.MaskV6,
+		AggThreshold:    y.Aggregation.Threshold,
+		DecayAfter:      time.Duration(y.Reaper.DecayAfterDays) * 24 * time.Hour,
+	}
+
+	if y.Reaper.Interval != "" {
+		d, err := time.ParseDuration(y.Reaper.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("reaper.interval: %w", err)
+		}
+		cfg.ReapInterval = d
+	}
+
+	for _, yf := range y.Feeds {
+		fc := FeedConfig{
+			Type:          yf.Type,
+			Path:          yf.Path,
+			Column:        yf.Column,
+			APIKey:        yf.APIKey,
+			Threshold:     yf.Threshold,
+			CollectionURL: yf.CollectionURL,
+			Username:      yf.Username,
+			Password:      yf.Password,
+			Interval:      DefaultFeedInterval,
+		}
+		if yf.Interval != "" {
+			d, err := time.ParseDuration(yf.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("feeds[%s].interval: %w", yf.Type, err)
+			}
+			fc.Interval = d
+		}
+		cfg.Feeds = append(cfg.Feeds, fc)
+	}
+
+	applyDefaults(cfg)
+
+	if err := validate(cfg, newLineIndex(raw)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func applyDefaults(cfg *Config) {
+	if cfg.HTTPAddr == "" {
+		cfg.HTTPAddr = DefaultHTTPAddr
+	}
+	if cfg.AggMaskV4 == 0 {
+		cfg.AggMaskV4 = DefaultAggMaskV4
+	}
+	if cfg.AggMaskV6 == 0 {
+		cfg.AggMaskV6 = DefaultAggMaskV6
+	}
+	if cfg.AggThreshold == 0 {
+		cfg.AggThreshold = DefaultAggThreshold
+	}
+	if cfg.ReapInterval == 0 {
+		cfg.ReapInterval = DefaultReapInterval
+	}
+	if cfg.DecayAfter == 0 {
+		cfg.DecayAfter = DefaultDecayAfterDays * 24 * time.Hour
+	}
+}
+
+// Validate reports the first configuration problem found, if any, with no
+// line number attached. Parse uses the line-aware validate instead; this
+// is exported for callers that build a Config without a YAML source.
+func Validate(cfg *Config) error {
+	return validate(cfg, nil)
+}
+
+// validate is Validate plus an optional lineIndex: when li resolves a
+// field's location, the error is prefixed with "line N:" so an operator
+// running validate-config can jump straight to it.
+func validate(cfg *Config, li *lineIndex) error {
+	if cfg.MtHost == "" {
+		return errf(li, "mikrotik.host", "mikrotik.host is required")
+	}
+	if cfg.MtUser == "" {
+		return errf(li, "mikrotik.user", "mikrotik.user is required")
+	}
+	if cfg.MtTLS.CertFile != "" && cfg.MtTLS.KeyFile == "" {
+		return errf(li, "mikrotik.tls.cert_file", "mikrotik.tls.cert_file requires mikrotik.tls.key_file")
+	}
+	if cfg.ListTemp == "" {
+		return errf(li, "lists.temp", "lists.temp is required")
+	}
+	if cfg.ListPerm == "" {
+		return errf(li, "lists.perm", "lists.perm is required")
+	}
+	if cfg.StateFile == "" {
+		return errf(li, "state_file", "state_file is required")
+	}
+	if len(cfg.Escalation) == 0 {
+		return errf(li, "escalation", "escalation must list at least one hour value")
+	}
+	if len(cfg.Feeds) == 0 {
+		return errf(li, "feeds", "feeds must list at least one feed")
+	}
+	for i, fc := range cfg.Feeds {
+		path := fmt.Sprintf("feeds[%d]", i)
+		switch fc.Type {
+		case "csv":
+			if fc.Path == "" {
+				return errf(li, path+".path", "feeds[%d]: csv feed requires path", i)
+			}
+		case "abuseipdb":
+			if fc.APIKey == "" {
+				return errf(li, path+".api_key", "feeds[%d]: abuseipdb feed requires api_key", i)
+			}
+		case "otx":
+			if fc.APIKey == "" {
+				return errf(li, path+".api_key", "feeds[%d]: otx feed requires api_key", i)
+			}
+		case "spamhaus":
+			// no required fields
+		case "stix":
+			if fc.CollectionURL == "" {
+				return errf(li, path+".collection_url", "feeds[%d]: stix feed requires collection_url", i)
+			}
+		case "":
+			return errf(li, path, "feeds[%d]: type is required", i)
+		default:
+			return errf(li, path+".type", "feeds[%d]: unknown feed type %q", i, fc.Type)
+		}
+	}
+	return nil
+}
+
+// errf builds a validation error, prefixing it with the field's line
+// number when li can resolve path.
+func errf(li *lineIndex, path, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if ln := li.line(path); ln > 0 {
+		return fmt.Errorf("line %d: %s", ln, msg)
+	}
+	return fmt.Errorf("%s", msg)
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const minimalYAML = `
+mikrotik:
+  host: 192.168.88.1:8728
+  user: admin
+  pass: secret
+lists:
+  temp: blocked_attackers
+  perm: blocked_permanent
+state_file: /opt/htb_blocker/state.db
+escalation: [1, 3, 7]
+feeds:
+  - type: csv
+    path: /opt/htb_blocker/attackers.csv
+`
+
+func TestParse_Minimal(t *testing.T) {
+	cfg, err := Parse([]byte(minimalYAML))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.MtHost != "192.168.88.1:8728" || cfg.ListTemp != "blocked_attackers" || cfg.ListPerm != "blocked_permanent" {
+		t.Fatalf("Parse populated cfg wrong: %+v", cfg)
+	}
+	if cfg.HTTPAddr != DefaultHTTPAddr {
+		t.Fatalf("HTTPAddr default = %q, want %q", cfg.HTTPAddr, DefaultHTTPAddr)
+	}
+	if cfg.AggMaskV4 != DefaultAggMaskV4 || cfg.AggMaskV6 != DefaultAggMaskV6 || cfg.AggThreshold != DefaultAggThreshold {
+		t.Fatalf("aggregation defaults not applied: %+v", cfg)
+	}
+	if cfg.ReapInterval != DefaultReapInterval {
+		t.Fatalf("ReapInterval default = %v, want %v", cfg.ReapInterval, DefaultReapInterval)
+	}
+	if cfg.DecayAfter != DefaultDecayAfterDays*24*time.Hour {
+		t.Fatalf("DecayAfter default = %v, want %v", cfg.DecayAfter, DefaultDecayAfterDays*24*time.Hour)
+	}
+	if len(cfg.Feeds) != 1 || cfg.Feeds[0].Interval != DefaultFeedInterval {
+		t.Fatalf("feed interval default not applied: %+v", cfg.Feeds)
+	}
+}
+
+func TestParse_UnknownFieldRejected(t *testing.T) {
+	raw := minimalYAML + "bogus_field: true\n"
+	_, err := Parse([]byte(raw))
+	if err == nil {
+		t.Fatal("expected an error for an unknown top-level field")
+	}
+	if !strings.Contains(err.Error(), "bogus_field") {
+		t.Fatalf("error = %v, want it to mention bogus_field", err)
+	}
+}
+
+func TestParse_MalformedFeedInterval(t *testing.T) {
+	raw := strings.Replace(minimalYAML, "path: /opt/htb_blocker/attackers.csv",
+		"path: /opt/htb_blocker/attackers.csv\n    interval: not-a-duration", 1)
+	_, err := Parse([]byte(raw))
+	if err == nil {
+		t.Fatal("expected an error for a malformed feeds[].interval")
+	}
+	if !strings.Contains(err.Error(), "feeds[csv].interval") {
+		t.Fatalf("error = %v, want it to name feeds[csv].interval", err)
+	}
+}
+
+func TestParse_MalformedReaperInterval(t *testing.T) {
+	raw := minimalYAML + "reaper:\n  interval: not-a-duration\n"
+	_, err := Parse([]byte(raw))
+	if err == nil {
+		t.Fatal("expected an error for a malformed reaper.interval")
+	}
+	if !strings.Contains(err.Error(), "reaper.interval") {
+		t.Fatalf("error = %v, want it to name reaper.interval", err)
+	}
+}
+
+func TestParse_MissingFieldReportsLine(t *testing.T) {
+	raw := strings.Replace(minimalYAML, "  perm: blocked_permanent\n", "", 1)
+	_, err := Parse([]byte(raw))
+	if err == nil {
+		t.Fatal("expected an error for missing lists.perm")
+	}
+	if !strings.Contains(err.Error(), "lists.perm is required") {
+		t.Fatalf("error = %v, want it to mention lists.perm is required", err)
+	}
+	if !strings.HasPrefix(err.Error(), "line ") {
+		t.Fatalf("error = %v, want a leading line number", err)
+	}
+}
+
+func TestValidate_RequiredFields(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			MtHost:     "host",
+			MtUser:     "user",
+			ListTemp:   "temp",
+			ListPerm:   "perm",
+			StateFile:  "state.db",
+			Escalation: []int{1},
+			Feeds:      []FeedConfig{{Type: "csv", Path: "x.csv"}},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"missing host", func(c *Config) { c.MtHost = "" }, "mikrotik.host is required"},
+		{"missing user", func(c *Config) { c.MtUser = "" }, "mikrotik.user is required"},
+		{"cert without key", func(c *Config) { c.MtTLS.CertFile = "cert.pem" }, "mikrotik.tls.cert_file requires mikrotik.tls.key_file"},
+		{"missing temp list", func(c *Config) { c.ListTemp = "" }, "lists.temp is required"},
+		{"missing perm list", func(c *Config) { c.ListPerm = "" }, "lists.perm is required"},
+		{"missing state file", func(c *Config) { c.StateFile = "" }, "state_file is required"},
+		{"empty escalation", func(c *Config) { c.Escalation = nil }, "escalation must list at least one hour value"},
+		{"no feeds", func(c *Config) { c.Feeds = nil }, "feeds must list at least one feed"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base()
+			tc.mutate(cfg)
+			err := Validate(cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_PerFeedTypeRequirements(t *testing.T) {
+	cases := []struct {
+		name    string
+		feed    FeedConfig
+		wantErr string
+	}{
+		{"csv missing path", FeedConfig{Type: "csv"}, "csv feed requires path"},
+		{"abuseipdb missing api_key", FeedConfig{Type: "abuseipdb"}, "abuseipdb feed requires api_key"},
+		{"otx missing api_key", FeedConfig{Type: "otx"}, "otx feed requires api_key"},
+		{"stix missing collection_url", FeedConfig{Type: "stix"}, "stix feed requires collection_url"},
+		{"missing type", FeedConfig{}, "type is required"},
+		{"unknown type", FeedConfig{Type: "bogus"}, `unknown feed type "bogus"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{
+				MtHost:     "host",
+				MtUser:     "user",
+				ListTemp:   "temp",
+				ListPerm:   "perm",
+				StateFile:  "state.db",
+				Escalation: []int{1},
+				Feeds:      []FeedConfig{tc.feed},
+			}
+			err := Validate(cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	t.Run("spamhaus has no required fields", func(t *testing.T) {
+		cfg := &Config{
+			MtHost:     "host",
+			MtUser:     "user",
+			ListTemp:   "temp",
+			ListPerm:   "perm",
+			StateFile:  "state.db",
+			Escalation: []int{1},
+			Feeds:      []FeedConfig{{Type: "spamhaus"}},
+		}
+		if err := Validate(cfg); err != nil {
+			t.Fatalf("Validate() = %v, want nil for a bare spamhaus feed", err)
+		}
+	})
+}
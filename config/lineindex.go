@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lineIndex resolves a dotted config path (e.g. "lists.perm",
+// "feeds[2].api_key") to the line it appears on in the original YAML, so
+// the errors Parse reports can point an operator at the right place. It is built from a
+// second, untyped decode of the same bytes Parse already validated, so a
+// lookup failure here never blocks parsing — it just means the resulting
+// error has no line number.
+type lineIndex struct {
+	root *yaml.Node
+}
+
+// newLineIndex builds a lineIndex from raw YAML, or returns nil if raw
+// can't be parsed as a generic node tree (best-effort; Parse has already
+// decoded raw once by the time this runs).
+func newLineIndex(raw []byte) *lineIndex {
+	var root yaml.Node
+	if err := yaml.Unmarshal(raw, &root); err != nil {
+		return nil
+	}
+	return &lineIndex{root: &root}
+}
+
+// line returns the best line number it can find for path: the field's own
+// line if present, otherwise the nearest ancestor that does exist (so a
+// missing key still points at the block it should have been added to). It
+// returns 0 if li is nil or no part of path could be resolved.
+func (li *lineIndex) line(path string) int {
+	if li == nil || li.root == nil {
+		return 0
+	}
+	cur := li.root
+	if cur.Kind == yaml.DocumentNode && len(cur.Content) > 0 {
+		cur = cur.Content[0]
+	}
+	last := cur.Line
+
+	for _, seg := range strings.Split(path, ".") {
+		key := seg
+		idx := -1
+		if b := strings.IndexByte(seg, '['); b >= 0 {
+			key = seg[:b]
+			if i, err := strconv.Atoi(strings.TrimSuffix(seg[b+1:], "]")); err == nil {
+				idx = i
+			}
+		}
+
+		if key != "" {
+			next := mappingValue(cur, key)
+			if next == nil {
+				return last
+			}
+			cur = next
+			last = cur.Line
+		}
+
+		if idx >= 0 {
+			if cur.Kind != yaml.SequenceNode || idx >= len(cur.Content) {
+				return last
+			}
+			cur = cur.Content[idx]
+			last = cur.Line
+		}
+	}
+	return last
+}
+
+// mappingValue returns the value node for key within node, or nil if node
+// isn't a mapping or doesn't contain key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
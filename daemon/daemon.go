@@ -0,0 +1,285 @@
+// Package daemon runs the blocker continuously: each feed is polled on its
+// own schedule, RouterOS writes retry with backoff, and /healthz plus
+// /metrics are exposed for operators. A config reload (SIGHUP, handled by
+// the caller) can add, drop, or re-interval feeds without restarting the
+// process or dropping the RouterOS session.
+package daemon
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/blocker"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/config"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/engine"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/state"
+)
+
+const (
+	retryAttempts = 5
+	retryBaseWait = 2 * time.Second
+)
+
+// ScheduledFeed pairs a feed with the poll interval it was configured with.
+type ScheduledFeed struct {
+	Feed     feed.Feed
+	Interval time.Duration
+}
+
+type feedRunner struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Daemon holds the long-running process's shared state.
+type Daemon struct {
+	blocker blocker.Blocker
+
+	// mu serializes engine.Process and state access across the
+	// concurrently-polling feed goroutines; Engine itself isn't safe for
+	// concurrent use.
+	mu     sync.Mutex
+	cfg    *config.Config
+	engine *engine.Engine
+	state  *state.Store
+
+	runnersMu sync.Mutex
+	runners   map[string]*feedRunner
+
+	reaperCancel context.CancelFunc
+	reaperDone   chan struct{}
+}
+
+// New returns a Daemon ready to Run.
+func New(cfg *config.Config, feeds []ScheduledFeed, blk blocker.Blocker, st *state.Store) *Daemon {
+	d := &Daemon{
+		blocker: blk,
+		cfg:     cfg,
+		engine:  engine.New(cfg),
+		state:   st,
+		runners: make(map[string]*feedRunner),
+	}
+	for _, sf := range feeds {
+		d.startFeed(sf)
+	}
+	d.startReaper()
+	return d
+}
+
+// Run starts the healthz/metrics server and blocks until ctx is canceled,
+// then flushes state and shuts down cleanly.
+func (d *Daemon) Run(ctx context.Context) error {
+	srv := d.startHTTPServer(ctx)
+
+	<-ctx.Done()
+	log.Println("🛑 shutting down, flushing state...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ http server shutdown: %v", err)
+	}
+
+	d.stopAllFeeds()
+	d.stopReaper()
+
+	d.mu.Lock()
+	st := d.state
+	d.mu.Unlock()
+	if err := st.Close(); err != nil {
+		log.Printf("⚠️ failed to close state store: %v", err)
+	}
+
+	return d.blocker.Close()
+}
+
+// Reload swaps in a newly loaded config and its feeds. The RouterOS
+// connection and in-memory state are preserved; feeds are restarted
+// against the new config so added/removed feeds and changed intervals
+// take effect immediately.
+func (d *Daemon) Reload(cfg *config.Config, feeds []ScheduledFeed) {
+	log.Println("🔄 reloading config...")
+
+	d.mu.Lock()
+	d.cfg = cfg
+	d.engine = engine.New(cfg)
+	d.mu.Unlock()
+
+	d.stopAllFeeds()
+	for _, sf := range feeds {
+		d.startFeed(sf)
+	}
+
+	d.stopReaper()
+	d.startReaper()
+
+	log.Printf("✅ reload complete, %d feed(s) active", len(feeds))
+}
+
+func (d *Daemon) startFeed(sf ScheduledFeed) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &feedRunner{cancel: cancel, done: make(chan struct{})}
+
+	d.runnersMu.Lock()
+	d.runners[sf.Feed.Name()] = r
+	d.runnersMu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		d.pollFeed(ctx, sf)
+	}()
+}
+
+func (d *Daemon) stopAllFeeds() {
+	d.runnersMu.Lock()
+	runners := d.runners
+	d.runners = make(map[string]*feedRunner)
+	d.runnersMu.Unlock()
+
+	for _, r := range runners {
+		r.cancel()
+	}
+	for _, r := range runners {
+		<-r.done
+	}
+}
+
+// startReaper launches the background pass that decays/expires state
+// records not seen within the configured DecayAfter window.
+func (d *Daemon) startReaper() {
+	d.mu.Lock()
+	interval := d.cfg.ReapInterval
+	decayAfter := d.cfg.DecayAfter
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	d.reaperCancel = cancel
+	d.reaperDone = done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.mu.Lock()
+				st := d.state
+				d.mu.Unlock()
+				decayed, expired, err := st.Reap(decayAfter)
+				if err != nil {
+					log.Printf("⚠️ reaper pass failed: %v", err)
+					continue
+				}
+				if decayed > 0 || expired > 0 {
+					log.Printf("🧹 reaper: decayed %d, expired %d", decayed, expired)
+				}
+			}
+		}
+	}()
+}
+
+func (d *Daemon) stopReaper() {
+	if d.reaperCancel == nil {
+		return
+	}
+	d.reaperCancel()
+	<-d.reaperDone
+	d.reaperCancel = nil
+}
+
+func (d *Daemon) startHTTPServer(ctx context.Context) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("/metrics", promhttp.Handler())
+
+	d.mu.Lock()
+	addr := d.cfg.HTTPAddr
+	d.mu.Unlock()
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ http server error: %v", err)
+		}
+	}()
+	log.Printf("📡 healthz/metrics listening on %s", addr)
+	return srv
+}
+
+func (d *Daemon) pollFeed(ctx context.Context, sf ScheduledFeed) {
+	interval := sf.Interval
+	if interval <= 0 {
+		interval = config.DefaultFeedInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	d.fetchAndProcess(ctx, sf.Feed)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.fetchAndProcess(ctx, sf.Feed)
+		}
+	}
+}
+
+func (d *Daemon) fetchAndProcess(ctx context.Context, f feed.Feed) {
+	start := time.Now()
+	indicators, err := f.Fetch(ctx)
+	feedFetchSeconds.WithLabelValues(f.Name()).Observe(time.Since(start).Seconds())
+	if err != nil {
+		feedFetchErrors.WithLabelValues(f.Name()).Inc()
+		log.Printf("❌ feed %s failed: %v", f.Name(), err)
+		return
+	}
+
+	for _, ind := range indicators {
+		d.processIndicator(ctx, ind)
+	}
+}
+
+func (d *Daemon) processIndicator(ctx context.Context, ind feed.Indicator) {
+	d.mu.Lock()
+	action, ok, err := d.engine.Process(ind, d.state, time.Now(), false)
+	size, sizeErr := d.state.Size()
+	d.mu.Unlock()
+
+	if sizeErr == nil {
+		stateSize.Set(float64(size))
+	}
+
+	if err != nil {
+		log.Printf("❌ state update failed for %s: %v", ind.Value, err)
+		return
+	}
+	if !ok {
+		log.Printf("⚪ %s → SKIP (invalid or whitelisted)", ind.Value)
+		return
+	}
+	escalationHistogram.Observe(float64(action.Count))
+
+	if err := blocker.WithRetry(ctx, retryAttempts, retryBaseWait, func() error {
+		return d.blocker.Block(ctx, action.List, action.Address, action.Timeout)
+	}); err != nil {
+		log.Printf("❌ block failed for %s after retries: %v", action.Address, err)
+		return
+	}
+
+	blocksAdded.WithLabelValues(action.List).Inc()
+	log.Printf("🛡️ %s → list %s, timeout %s (%s)", action.Address, action.List, action.Timeout, ind.Source)
+}
@@ -0,0 +1,35 @@
+package daemon
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	blocksAdded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blocker_blocks_added_total",
+		Help: "Address-list entries added, by list.",
+	}, []string{"list"})
+
+	feedFetchSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "blocker_feed_fetch_seconds",
+		Help: "Feed fetch latency.",
+	}, []string{"feed"})
+
+	feedFetchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "blocker_feed_fetch_errors_total",
+		Help: "Feed fetch failures, by feed.",
+	}, []string{"feed"})
+
+	stateSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "blocker_state_size",
+		Help: "Number of addresses currently tracked in state.",
+	})
+
+	escalationHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "blocker_escalation_count",
+		Help:    "Escalation count an address reached when blocked.",
+		Buckets: prometheus.LinearBuckets(1, 1, 10),
+	})
+)
+
+func init() {
+	prometheus.MustRegister(blocksAdded, feedFetchSeconds, feedFetchErrors, stateSize, escalationHistogram)
+}
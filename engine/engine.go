@@ -0,0 +1,191 @@
+// Package engine turns feed indicators into address-list actions,
+// applying whitelisting, per-host escalation, and subnet aggregation.
+package engine
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/blocker"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/config"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/netutil"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/state"
+)
+
+// Action is a single address-list mutation to apply via a Blocker.
+type Action struct {
+	List    string
+	Address string
+	Timeout string
+	// Count is the escalation count (hits on the host, or on the subnet
+	// once aggregated) that produced Timeout, exposed for metrics.
+	Count int
+	// ExpiresAt is when RouterOS will drop this entry on its own ("0"
+	// timeout leaves it zero, meaning permanent). It is stored on the
+	// escalation record; the reaper does not read it and works from
+	// LastSeen alone.
+	ExpiresAt time.Time
+}
+
+// Engine decides what to do with each indicator against a shared Store.
+// It is not safe for concurrent use; callers running multiple feeds
+// concurrently (daemon mode) must serialize calls to Process.
+type Engine struct {
+	cfg       *config.Config
+	whitelist []netip.Prefix
+}
+
+// New returns an Engine driven by cfg.
+func New(cfg *config.Config) *Engine {
+	return &Engine{cfg: cfg, whitelist: netutil.ParseWhitelist(cfg.Whitelist)}
+}
+
+// Process computes the Action for ind against st's current records. ok is
+// false when ind should be skipped (unparseable or whitelisted). When
+// dryRun is true, Process only reads st to preview what would happen; it
+// never writes a record or an audit entry, so repeated --dry-run runs are
+// idempotent and a later real run escalates from the same starting point.
+func (e *Engine) Process(ind feed.Indicator, st *state.Store, now time.Time, dryRun bool) (action Action, ok bool, err error) {
+	prefix, valid := netutil.ParsePrefix(ind.Value)
+	if !valid {
+		return Action{}, false, nil
+	}
+	if netutil.IsWhitelisted(prefix.Addr(), e.whitelist) {
+		return Action{}, false, nil
+	}
+
+	if !netutil.IsHost(prefix) {
+		// The feed already reported a genuine range (e.g. Spamhaus DROP);
+		// escalate it directly on the range table.
+		action, err = e.escalateSubnet(prefix, ind, st, now, dryRun)
+		return action, err == nil, err
+	}
+
+	addr := prefix.Addr()
+	subnet := netutil.ContainingPrefix(addr, e.cfg.AggMaskV4, e.cfg.AggMaskV6)
+	subnetKey := subnet.String()
+
+	subnetRec, err := st.GetSubnet(subnetKey)
+	if err != nil {
+		return Action{}, false, err
+	}
+	if subnetRec.Aggregated {
+		action, err = e.escalateSubnet(subnet, ind, st, now, dryRun)
+		return action, err == nil, err
+	}
+
+	hostKey := prefix.String()
+	hostRec, err := st.GetHost(hostKey)
+	if err != nil {
+		return Action{}, false, err
+	}
+	if hostRec.FirstSeen.IsZero() {
+		hostRec.FirstSeen = now
+	}
+	hostRec.Count += ind.Severity.Weight()
+	hostRec.LastSeen = now
+	hostRec.Source = ind.Source
+
+	if subnetRec.FirstSeen.IsZero() {
+		subnetRec.FirstSeen = now
+	}
+	subnetRec.Count++
+	subnetRec.LastSeen = now
+	subnetRec.Source = ind.Source
+
+	if subnetRec.Count >= e.cfg.AggThreshold {
+		subnetRec.Aggregated = true
+		a := e.buildAction(subnetKey, blocker.GetTimeout(subnetRec.Count, e.cfg.EscalationRange), subnetRec.Count, now)
+		subnetRec.ExpiresAt = a.ExpiresAt
+		if dryRun {
+			return a, true, nil
+		}
+		if err := st.ForgetHostsIn(subnet); err != nil {
+			return Action{}, false, err
+		}
+		if err := st.PutSubnet(subnetKey, subnetRec); err != nil {
+			return Action{}, false, err
+		}
+		if err := st.Audit(auditEvent(a, now, "escalate")); err != nil {
+			return Action{}, false, err
+		}
+		return a, true, nil
+	}
+
+	a := e.buildAction(addr.String(), blocker.GetTimeout(hostRec.Count, e.cfg.Escalation), hostRec.Count, now)
+	if dryRun {
+		return a, true, nil
+	}
+
+	hostRec.ExpiresAt = a.ExpiresAt
+	if err := st.PutHost(hostKey, hostRec); err != nil {
+		return Action{}, false, err
+	}
+	if err := st.PutSubnet(subnetKey, subnetRec); err != nil {
+		return Action{}, false, err
+	}
+	kind := "add"
+	if hostRec.Count > 1 {
+		kind = "escalate"
+	}
+	if err := st.Audit(auditEvent(a, now, kind)); err != nil {
+		return Action{}, false, err
+	}
+	return a, true, nil
+}
+
+func (e *Engine) escalateSubnet(subnet netip.Prefix, ind feed.Indicator, st *state.Store, now time.Time, dryRun bool) (Action, error) {
+	key := subnet.String()
+	rec, err := st.GetSubnet(key)
+	if err != nil {
+		return Action{}, err
+	}
+	if rec.FirstSeen.IsZero() {
+		rec.FirstSeen = now
+	}
+	rec.Count += ind.Severity.Weight()
+	rec.LastSeen = now
+	rec.Source = ind.Source
+	rec.Aggregated = true
+
+	a := e.buildAction(key, blocker.GetTimeout(rec.Count, e.cfg.EscalationRange), rec.Count, now)
+	if dryRun {
+		return a, nil
+	}
+
+	rec.ExpiresAt = a.ExpiresAt
+	if err := st.PutSubnet(key, rec); err != nil {
+		return Action{}, err
+	}
+	kind := "add"
+	if rec.Count > ind.Severity.Weight() {
+		kind = "escalate"
+	}
+	if err := st.Audit(auditEvent(a, now, kind)); err != nil {
+		return Action{}, err
+	}
+	return a, nil
+}
+
+func (e *Engine) buildAction(address, timeout string, count int, now time.Time) Action {
+	list := e.cfg.ListTemp
+	if timeout == "0" {
+		list = e.cfg.ListPerm
+	}
+	action := Action{List: list, Address: address, Timeout: timeout, Count: count}
+	if d, ok := blocker.ParseTimeout(timeout); ok {
+		action.ExpiresAt = now.Add(d)
+	}
+	return action
+}
+
+func auditEvent(a Action, now time.Time, kind string) state.AuditEvent {
+	return state.AuditEvent{
+		Time:    now,
+		Action:  kind,
+		Key:     a.Address,
+		List:    a.List,
+		Timeout: a.Timeout,
+	}
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/config"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/state"
+)
+
+func newTestEngine(t *testing.T) (*Engine, *state.Store) {
+	t.Helper()
+
+	cfg := &config.Config{
+		ListTemp:        "temp",
+		ListPerm:        "perm",
+		Escalation:      []int{1, 3, 7},
+		EscalationRange: []int{1, 3, 7},
+		AggMaskV4:       24,
+		AggMaskV6:       64,
+		AggThreshold:    3,
+	}
+
+	st, err := state.Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+
+	return New(cfg), st
+}
+
+func TestProcess_HostEscalation(t *testing.T) {
+	eng, st := newTestEngine(t)
+	now := time.Now()
+	ind := feed.Indicator{Value: "1.2.3.4", Source: "test", Severity: feed.SeverityLow}
+
+	a1, ok, err := eng.Process(ind, st, now, false)
+	if err != nil || !ok {
+		t.Fatalf("Process #1: ok=%v err=%v", ok, err)
+	}
+	if a1.Address != "1.2.3.4" || a1.Timeout != "01:00:00" || a1.List != "temp" {
+		t.Fatalf("Process #1 = %+v, want first-hit action on temp list", a1)
+	}
+
+	a2, ok, err := eng.Process(ind, st, now, false)
+	if err != nil || !ok {
+		t.Fatalf("Process #2: ok=%v err=%v", ok, err)
+	}
+	if a2.Timeout != "03:00:00" {
+		t.Fatalf("Process #2 timeout = %q, want escalated 03:00:00", a2.Timeout)
+	}
+}
+
+func TestProcess_AggregationCrossover(t *testing.T) {
+	eng, st := newTestEngine(t)
+	now := time.Now()
+
+	hosts := []string{"1.2.3.4", "1.2.3.5", "1.2.3.6"}
+	var last Action
+	for _, h := range hosts {
+		ind := feed.Indicator{Value: h, Source: "test", Severity: feed.SeverityLow}
+		a, ok, err := eng.Process(ind, st, now, false)
+		if err != nil || !ok {
+			t.Fatalf("Process(%s): ok=%v err=%v", h, ok, err)
+		}
+		last = a
+	}
+
+	// The third distinct host should have crossed AggThreshold (3) and been
+	// escalated as the whole /24 instead of as its own host.
+	if last.Address != "1.2.3.0/24" {
+		t.Fatalf("aggregation crossover action = %+v, want subnet 1.2.3.0/24", last)
+	}
+
+	for _, h := range hosts[:2] {
+		rec, err := st.GetHost(h + "/32")
+		if err != nil {
+			t.Fatalf("GetHost(%s): %v", h, err)
+		}
+		if rec.Count != 0 {
+			t.Fatalf("expected host record for %s to be forgotten after aggregation, got %+v", h, rec)
+		}
+	}
+
+	subnetRec, err := st.GetSubnet("1.2.3.0/24")
+	if err != nil {
+		t.Fatalf("GetSubnet: %v", err)
+	}
+	if !subnetRec.Aggregated {
+		t.Fatal("expected subnet record to be marked Aggregated")
+	}
+}
+
+func TestProcess_AlreadyAggregated(t *testing.T) {
+	eng, st := newTestEngine(t)
+	now := time.Now()
+
+	for _, h := range []string{"1.2.3.4", "1.2.3.5", "1.2.3.6"} {
+		ind := feed.Indicator{Value: h, Source: "test", Severity: feed.SeverityLow}
+		if _, _, err := eng.Process(ind, st, now, false); err != nil {
+			t.Fatalf("Process(%s): %v", h, err)
+		}
+	}
+
+	// A new host in the same /24, after aggregation has already kicked in,
+	// should escalate the subnet directly rather than starting a new host
+	// record. The subnet is already at count 3 (the EscalationRange table
+	// length), so this 4th hit runs past the table and is permanent.
+	ind := feed.Indicator{Value: "1.2.3.200", Source: "test", Severity: feed.SeverityLow}
+	a, ok, err := eng.Process(ind, st, now, false)
+	if err != nil || !ok {
+		t.Fatalf("Process: ok=%v err=%v", ok, err)
+	}
+	if a.Address != "1.2.3.0/24" {
+		t.Fatalf("already-aggregated action = %+v, want subnet 1.2.3.0/24", a)
+	}
+	if a.Timeout != "0" || a.List != "perm" {
+		t.Fatalf("already-aggregated action = %+v, want permanent perm-list block (4th hit)", a)
+	}
+
+	if rec, err := st.GetHost("1.2.3.200/32"); err != nil {
+		t.Fatalf("GetHost: %v", err)
+	} else if rec.Count != 0 {
+		t.Fatalf("expected no host record once subnet is aggregated, got %+v", rec)
+	}
+}
+
+func TestProcess_DryRunDoesNotMutate(t *testing.T) {
+	eng, st := newTestEngine(t)
+	now := time.Now()
+	ind := feed.Indicator{Value: "1.2.3.4", Source: "test", Severity: feed.SeverityLow}
+
+	a, ok, err := eng.Process(ind, st, now, true)
+	if err != nil || !ok {
+		t.Fatalf("Process: ok=%v err=%v", ok, err)
+	}
+	if a.Timeout != "01:00:00" {
+		t.Fatalf("dry-run action = %+v, want first-hit preview", a)
+	}
+
+	rec, err := st.GetHost("1.2.3.4/32")
+	if err != nil {
+		t.Fatalf("GetHost: %v", err)
+	}
+	if rec.Count != 0 {
+		t.Fatalf("dry-run must not persist a host record, got %+v", rec)
+	}
+
+	events, err := st.AuditEvents()
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("dry-run must not write audit events, got %d", len(events))
+	}
+
+	// Running it again should produce the exact same preview.
+	a2, ok, err := eng.Process(ind, st, now, true)
+	if err != nil || !ok {
+		t.Fatalf("Process (repeat): ok=%v err=%v", ok, err)
+	}
+	if a2 != a {
+		t.Fatalf("repeated dry-run action = %+v, want identical %+v", a2, a)
+	}
+}
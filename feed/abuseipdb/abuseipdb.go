@@ -0,0 +1,84 @@
+// Package abuseipdb ingests the AbuseIPDB blacklist endpoint.
+package abuseipdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+)
+
+const blacklistURL = "https://api.abuseipdb.com/api/v2/blacklist"
+
+// Feed pulls the AbuseIPDB blacklist, filtered to entries at or above
+// Threshold confidence (0-100).
+type Feed struct {
+	APIKey     string
+	Threshold  int
+	HTTPClient *http.Client
+}
+
+// New returns an AbuseIPDB feed authenticated with apiKey, only reporting
+// indicators at or above the given confidence threshold.
+func New(apiKey string, threshold int) *Feed {
+	return &Feed{APIKey: apiKey, Threshold: threshold, HTTPClient: http.DefaultClient}
+}
+
+func (f *Feed) Name() string { return "abuseipdb" }
+
+type blacklistResponse struct {
+	Data []struct {
+		IPAddress            string `json:"ipAddress"`
+		AbuseConfidenceScore int    `json:"abuseConfidenceScore"`
+	} `json:"data"`
+}
+
+func (f *Feed) Fetch(ctx context.Context) ([]feed.Indicator, error) {
+	url := fmt.Sprintf("%s?confidenceMinimum=%d", blacklistURL, f.Threshold)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Key", f.APIKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("abuseipdb: unexpected status %s", resp.Status)
+	}
+
+	var body blacklistResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("abuseipdb: decode response: %w", err)
+	}
+
+	out := make([]feed.Indicator, 0, len(body.Data))
+	for _, e := range body.Data {
+		out = append(out, feed.Indicator{
+			Value:      e.IPAddress,
+			Source:     f.Name(),
+			Confidence: e.AbuseConfidenceScore,
+			Severity:   severityFor(e.AbuseConfidenceScore),
+		})
+	}
+	return out, nil
+}
+
+func severityFor(confidence int) feed.Severity {
+	switch {
+	case confidence >= 95:
+		return feed.SeverityCritical
+	case confidence >= 80:
+		return feed.SeverityHigh
+	case confidence >= 50:
+		return feed.SeverityMedium
+	default:
+		return feed.SeverityLow
+	}
+}
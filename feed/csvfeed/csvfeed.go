@@ -0,0 +1,88 @@
+// Package csvfeed reads attacker IPs from a generic HTTP or local CSV file.
+package csvfeed
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+)
+
+// Feed reads a single configurable column out of a CSV file or URL. Local
+// paths are read directly; anything starting with "http://" or "https://"
+// is fetched first.
+type Feed struct {
+	Source string // file path or URL
+	Column int    // 0-indexed column containing the IP/CIDR, default 0
+}
+
+// New returns a CSV feed over the given path or URL.
+func New(source string, column int) *Feed {
+	return &Feed{Source: source, Column: column}
+}
+
+func (f *Feed) Name() string { return "csv:" + f.Source }
+
+func (f *Feed) Fetch(ctx context.Context) ([]feed.Indicator, error) {
+	var r interface {
+		Scan() bool
+		Text() string
+	}
+
+	if strings.HasPrefix(f.Source, "http://") || strings.HasPrefix(f.Source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.Source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("csvfeed: %s returned %s", f.Source, resp.Status)
+		}
+		r = bufio.NewScanner(resp.Body)
+	} else {
+		file, err := os.Open(f.Source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = bufio.NewScanner(file)
+	}
+
+	var out []feed.Indicator
+	line := 0
+	for r.Scan() {
+		v := strings.TrimSpace(r.Text())
+		if line == 0 {
+			line++
+			continue // header row
+		}
+		line++
+		if v == "" {
+			continue
+		}
+		cols := strings.Split(v, ",")
+		col := f.Column
+		if col >= len(cols) {
+			col = 0
+		}
+		value := strings.Trim(strings.TrimSpace(cols[col]), "\"")
+		if value == "" {
+			continue
+		}
+		out = append(out, feed.Indicator{
+			Value:      value,
+			Source:     f.Name(),
+			Confidence: 50,
+			Severity:   feed.SeverityMedium,
+		})
+	}
+	return out, nil
+}
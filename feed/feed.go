@@ -0,0 +1,45 @@
+// Package feed defines the pluggable ingestion interface that all threat
+// feeds (AbuseIPDB, AlienVault OTX, Spamhaus, CSV, STIX/TAXII, ...) implement.
+package feed
+
+import "context"
+
+// Severity is the confidence/severity tier a feed assigns to an indicator.
+// It maps onto the escalation tables used when deciding block timeouts.
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// Weight returns how many escalation "hits" a single sighting at this
+// severity counts as, so higher-confidence feeds escalate faster than a
+// bare CSV drop.
+func (s Severity) Weight() int {
+	switch s {
+	case SeverityCritical:
+		return 3
+	case SeverityHigh:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Indicator is a single attacker address or range reported by a feed.
+type Indicator struct {
+	Value      string // IP or CIDR
+	Source     string
+	Confidence int // 0-100, feed-specific scale normalized where possible
+	Severity   Severity
+}
+
+// Feed is implemented by every ingester. Fetch should return the current
+// snapshot of indicators; feeds are polled, not streamed.
+type Feed interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Indicator, error)
+}
@@ -0,0 +1,75 @@
+// Package otx ingests IPv4 indicators from AlienVault OTX pulses the
+// configured API key is subscribed to.
+package otx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+)
+
+const pulsesURL = "https://otx.alienvault.com/api/v1/pulses/subscribed"
+
+// Feed pulls subscribed pulses and extracts their IPv4 indicators.
+type Feed struct {
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// New returns an OTX feed authenticated with apiKey.
+func New(apiKey string) *Feed {
+	return &Feed{APIKey: apiKey, HTTPClient: http.DefaultClient}
+}
+
+func (f *Feed) Name() string { return "otx" }
+
+type pulsesResponse struct {
+	Results []struct {
+		Name       string `json:"name"`
+		Indicators []struct {
+			Type      string `json:"type"`
+			Indicator string `json:"indicator"`
+		} `json:"indicators"`
+	} `json:"results"`
+}
+
+func (f *Feed) Fetch(ctx context.Context) ([]feed.Indicator, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pulsesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-OTX-API-KEY", f.APIKey)
+
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("otx: unexpected status %s", resp.Status)
+	}
+
+	var body pulsesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("otx: decode response: %w", err)
+	}
+
+	var out []feed.Indicator
+	for _, pulse := range body.Results {
+		for _, ind := range pulse.Indicators {
+			if ind.Type != "IPv4" {
+				continue
+			}
+			out = append(out, feed.Indicator{
+				Value:      ind.Indicator,
+				Source:     f.Name() + ":" + pulse.Name,
+				Confidence: 70,
+				Severity:   feed.SeverityHigh,
+			})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,84 @@
+// Package spamhaus ingests the plaintext Spamhaus DROP/EDROP lists.
+package spamhaus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+)
+
+const (
+	// DropURL is the standard DROP (Don't Route Or Peer) list.
+	DropURL = "https://www.spamhaus.org/drop/drop.txt"
+	// EdropURL is the extended DROP list covering additional ranges.
+	EdropURL = "https://www.spamhaus.org/drop/edrop.txt"
+)
+
+// Feed pulls one or more Spamhaus DROP-format lists. Lines are
+// "CIDR ; SBL-ref" with ";" starting a comment.
+type Feed struct {
+	URLs       []string
+	HTTPClient *http.Client
+}
+
+// New returns a Spamhaus feed covering the given list URLs, defaulting to
+// DROP and EDROP when none are given.
+func New(urls ...string) *Feed {
+	if len(urls) == 0 {
+		urls = []string{DropURL, EdropURL}
+	}
+	return &Feed{URLs: urls, HTTPClient: http.DefaultClient}
+}
+
+func (f *Feed) Name() string { return "spamhaus" }
+
+func (f *Feed) Fetch(ctx context.Context) ([]feed.Indicator, error) {
+	var out []feed.Indicator
+	for _, url := range f.URLs {
+		indicators, err := f.fetchOne(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("spamhaus: %s: %w", url, err)
+		}
+		out = append(out, indicators...)
+	}
+	return out, nil
+}
+
+func (f *Feed) fetchOne(ctx context.Context, url string) ([]feed.Indicator, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var out []feed.Indicator
+	s := bufio.NewScanner(resp.Body)
+	for s.Scan() {
+		ln := strings.TrimSpace(s.Text())
+		if ln == "" || strings.HasPrefix(ln, ";") {
+			continue
+		}
+		cidr := strings.TrimSpace(strings.SplitN(ln, ";", 2)[0])
+		if cidr == "" {
+			continue
+		}
+		out = append(out, feed.Indicator{
+			Value:      cidr,
+			Source:     f.Name(),
+			Confidence: 100,
+			Severity:   feed.SeverityCritical,
+		})
+	}
+	return out, s.Err()
+}
@@ -0,0 +1,89 @@
+// Package stix polls a STIX/TAXII 2.1 collection's objects endpoint for
+// indicator objects carrying an ipv4-addr pattern.
+package stix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+)
+
+// ipv4Pattern extracts the address out of a STIX pattern such as
+// "[ipv4-addr:value = '203.0.113.1']".
+var ipv4Pattern = regexp.MustCompile(`ipv4-addr:value\s*=\s*'([^']+)'`)
+
+// Feed polls a single TAXII 2.1 collection's /objects/ endpoint.
+type Feed struct {
+	// CollectionURL is the full .../collections/{id}/objects/ URL.
+	CollectionURL string
+	APIRoot       string // informational only; Fetch and Name use CollectionURL
+	Username      string
+	Password      string
+	HTTPClient    *http.Client
+}
+
+// New returns a STIX/TAXII feed polling collectionURL with optional basic auth.
+func New(collectionURL, username, password string) *Feed {
+	return &Feed{
+		CollectionURL: collectionURL,
+		Username:      username,
+		Password:      password,
+		HTTPClient:    http.DefaultClient,
+	}
+}
+
+func (f *Feed) Name() string { return "stix:" + f.CollectionURL }
+
+type taxiiEnvelope struct {
+	Objects []struct {
+		Type    string `json:"type"`
+		Pattern string `json:"pattern"`
+	} `json:"objects"`
+}
+
+func (f *Feed) Fetch(ctx context.Context) ([]feed.Indicator, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.CollectionURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/taxii+json;version=2.1")
+	if f.Username != "" {
+		req.SetBasicAuth(f.Username, f.Password)
+	}
+
+	resp, err := f.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stix: unexpected status %s", resp.Status)
+	}
+
+	var body taxiiEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("stix: decode response: %w", err)
+	}
+
+	var out []feed.Indicator
+	for _, obj := range body.Objects {
+		if obj.Type != "indicator" {
+			continue
+		}
+		m := ipv4Pattern.FindStringSubmatch(obj.Pattern)
+		if m == nil {
+			continue
+		}
+		out = append(out, feed.Indicator{
+			Value:      m[1],
+			Source:     f.Name(),
+			Confidence: 80,
+			Severity:   feed.SeverityHigh,
+		})
+	}
+	return out, nil
+}
@@ -1,328 +1,364 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net"
+	"log/syslog"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strconv"
-	"strings"
-
-	"github.com/go-routeros/routeros"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/blocker"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/config"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/daemon"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/engine"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed/abuseipdb"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed/csvfeed"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed/otx"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed/spamhaus"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/feed/stix"
+	"github.com/htayanloo/RouterOS-Blocklist-Sync/state"
 )
 
-type Config struct {
-	MtHost     string
-	MtUser     string
-	MtPass     string
-	ListTemp   string
-	ListPerm   string
-	Whitelist  []string
-	StateFile  string
-	Escalation []int
-}
-
-// ----------------------------------------------
-// AUTO-SETUP: check & create /opt/htb_blocker
-// ----------------------------------------------
-
-func ensureBaseDir(base string) {
-	if _, err := os.Stat(base); os.IsNotExist(err) {
-		fmt.Printf("⚠️  Folder %s does not exist. Create it? (y/n): ", base)
-
-		var answer string
-		fmt.Scanln(&answer)
+const defaultConfigPath = "/opt/htb_blocker/config.yaml"
 
-		answer = strings.ToLower(strings.TrimSpace(answer))
-		if answer != "y" && answer != "yes" {
-			log.Fatal("❌ Aborted by user.")
-		}
+var configFlag = &cli.StringFlag{
+	Name:  "config",
+	Value: defaultConfigPath,
+	Usage: "path to config.yaml",
+}
 
-		fmt.Println("➡️ Creating folder:", base)
-		os.MkdirAll(base, 0755)
+// newFeed builds the live feed.Feed for a single FeedConfig, or returns
+// ok=false if fc.Type isn't recognized. Keeping this as a one-in-one-out
+// helper (rather than filtering a slice) is what lets buildFeeds and
+// buildScheduledFeeds drop an unrecognized feed without risking any
+// index-based state, like a per-feed interval, getting out of sync with
+// the feed it belongs to.
+func newFeed(fc config.FeedConfig) (f feed.Feed, ok bool) {
+	switch fc.Type {
+	case "csv":
+		return csvfeed.New(fc.Path, fc.Column), true
+	case "abuseipdb":
+		return abuseipdb.New(fc.APIKey, fc.Threshold), true
+	case "otx":
+		return otx.New(fc.APIKey), true
+	case "spamhaus":
+		return spamhaus.New(), true
+	case "stix":
+		return stix.New(fc.CollectionURL, fc.Username, fc.Password), true
+	default:
+		log.Printf("⚠️ Unknown feed type %q, skipping", fc.Type)
+		return nil, false
 	}
 }
 
-// ----------------------------------------------
-// AUTO-SETUP: If config.env missing → create default
-// ----------------------------------------------
-
-func ensureConfig(base string) {
-	configPath := filepath.Join(base, "config.env")
-
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-
-		fmt.Printf("⚠️  config.env not found. Create default config? (y/n): ")
-
-		var answer string
-		fmt.Scanln(&answer)
-		answer = strings.ToLower(strings.TrimSpace(answer))
-		if answer != "y" {
-			log.Fatal("❌ Aborted by user.")
+// buildFeeds turns configured FeedConfig entries into live Feed instances,
+// skipping any entry whose type newFeed does not recognize.
+func buildFeeds(cfgs []config.FeedConfig) []feed.Feed {
+	var feeds []feed.Feed
+	for _, fc := range cfgs {
+		if f, ok := newFeed(fc); ok {
+			feeds = append(feeds, f)
 		}
-
-		defaultCfg := `
-# MikroTik settings
-MT_HOST=192.168.88.1:8728
-MT_USER=admin
-MT_PASS=yourpassword
-
-# Lists
-LIST_TEMP=blocked_attackers
-LIST_PERM=blocked_permanent
-
-# Whitelist (comma separated)
-WHITELIST=8.8.8.8,192.168.1.0/24
-
-# State file
-STATE_FILE=/opt/htb_blocker/state.json
-
-# Escalation (hours)
-ESCALATE_1=1
-ESCALATE_2=3
-ESCALATE_3=7
-`
-		os.WriteFile(configPath, []byte(defaultCfg), 0644)
-
-		fmt.Println("✅ Default config.env created. Please edit it.")
 	}
+	return feeds
 }
-func sanitizeIP(raw string) string {
-	// Trim quotes
-	raw = strings.Trim(raw, "\"")
-
-	// Split on comma (CSV)
-	if strings.Contains(raw, ",") {
-		parts := strings.Split(raw, ",")
-		raw = parts[0]
-	}
 
-	// Remove spaces
-	raw = strings.TrimSpace(raw)
-
-	// Validate
-	ip := net.ParseIP(raw)
-	if ip == nil {
-		return ""
+func buildScheduledFeeds(cfg *config.Config) []daemon.ScheduledFeed {
+	var scheduled []daemon.ScheduledFeed
+	for _, fc := range cfg.Feeds {
+		if f, ok := newFeed(fc); ok {
+			scheduled = append(scheduled, daemon.ScheduledFeed{Feed: f, Interval: fc.Interval})
+		}
 	}
-	return ip.String()
+	return scheduled
 }
 
-// ----------------------------------------------
-
-func loadConfig(path string) (*Config, error) {
-	cfg := &Config{}
-	raw, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	lines := strings.Split(string(raw), "\n")
-	for _, ln := range lines {
-		ln = strings.TrimSpace(ln)
-		if ln == "" || strings.HasPrefix(ln, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(ln, "=", 2)
-		if len(parts) != 2 {
+// fetchAll polls every feed and merges indicators for the same value,
+// keeping the one with the highest severity seen across feeds; on equal
+// severity the first one seen is kept.
+func fetchAll(ctx context.Context, feeds []feed.Feed) []feed.Indicator {
+	merged := make(map[string]feed.Indicator)
+	for _, f := range feeds {
+		indicators, err := f.Fetch(ctx)
+		if err != nil {
+			log.Printf("❌ Feed %s failed: %v", f.Name(), err)
 			continue
 		}
-
-		key := parts[0]
-		val := parts[1]
-
-		switch key {
-		case "MT_HOST":
-			cfg.MtHost = val
-		case "MT_USER":
-			cfg.MtUser = val
-		case "MT_PASS":
-			cfg.MtPass = val
-		case "LIST_TEMP":
-			cfg.ListTemp = val
-		case "LIST_PERM":
-			cfg.ListPerm = val
-		case "WHITELIST":
-			cfg.Whitelist = strings.Split(val, ",")
-		case "STATE_FILE":
-			cfg.StateFile = val
-		case "ESCALATE_1", "ESCALATE_2", "ESCALATE_3":
-			h, _ := strconv.Atoi(val)
-			cfg.Escalation = append(cfg.Escalation, h)
+		for _, ind := range indicators {
+			existing, ok := merged[ind.Value]
+			if !ok || ind.Severity > existing.Severity {
+				merged[ind.Value] = ind
+			}
 		}
 	}
 
-	return cfg, nil
+	out := make([]feed.Indicator, 0, len(merged))
+	for _, ind := range merged {
+		out = append(out, ind)
+	}
+	return out
 }
 
 // ----------------------------------------------
 
-func loadIPsFromCSV(path string) ([]string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// dialBlocker connects plaintext or over API-SSL depending on cfg.MtTLS.
+func dialBlocker(cfg *config.Config) (*blocker.RouterOSBlocker, error) {
+	if cfg.MtTLS.Enabled {
+		return blocker.DialTLS(cfg.MtHost, cfg.MtUser, cfg.MtPass, blocker.TLSConfig{
+			CAFile:       cfg.MtTLS.CAFile,
+			CertFile:     cfg.MtTLS.CertFile,
+			KeyFile:      cfg.MtTLS.KeyFile,
+			PinnedSHA256: cfg.MtTLS.PinnedSHA256,
+		})
 	}
-	defer f.Close()
+	return blocker.Dial(cfg.MtHost, cfg.MtUser, cfg.MtPass)
+}
 
-	var ips []string
-	s := bufio.NewScanner(f)
-	line := 0
+func runOnce(cfg *config.Config, dryRun bool) error {
+	ctx := context.Background()
 
-	for s.Scan() {
-		v := strings.TrimSpace(s.Text())
-		if line == 0 {
-			line++
-			continue
-		}
-		v = strings.Trim(v, "\"")
-		if v != "" {
-			ips = append(ips, v)
-		}
-		line++
+	st, err := state.Open(cfg.StateFile)
+	if err != nil {
+		return fmt.Errorf("state open error: %w", err)
 	}
-	return ips, nil
-}
+	defer st.Close()
 
-// ----------------------------------------------
+	feeds := buildFeeds(cfg.Feeds)
+	indicators := fetchAll(ctx, feeds)
 
-func isWhitelisted(ip string, wl []string) bool {
-	p := net.ParseIP(ip)
-	if p == nil {
-		return false
+	b, err := dialBlocker(cfg)
+	if err != nil {
+		return fmt.Errorf("mikrotik error: %w", err)
 	}
+	defer b.Close()
 
-	for _, entry := range wl {
-		entry = strings.TrimSpace(entry)
-		if !strings.Contains(entry, "/") {
-			if entry == ip {
-				return true
-			}
-			continue
+	eng := engine.New(cfg)
+	actions := make([]engine.Action, 0, len(indicators))
+	for _, ind := range indicators {
+		action, ok, err := eng.Process(ind, st, time.Now(), dryRun)
+		if err != nil {
+			return fmt.Errorf("state update error: %w", err)
 		}
-		_, cidr, err := net.ParseCIDR(entry)
-		if err == nil && cidr.Contains(p) {
-			return true
+		if !ok {
+			log.Printf("⚪ %s → SKIP (invalid or whitelisted)", ind.Value)
+			continue
 		}
+		actions = append(actions, action)
 	}
-	return false
-}
 
-// ----------------------------------------------
+	if dryRun {
+		return printDiff(ctx, b, cfg, actions)
+	}
 
-func loadState(path string) (map[string]int, error) {
-	raw, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return make(map[string]int), nil
+	for _, action := range actions {
+		log.Printf("🛡️ %s → list %s, timeout %s", action.Address, action.List, action.Timeout)
+		if err := b.Block(ctx, action.List, action.Address, action.Timeout); err != nil {
+			log.Printf("❌ Block failed for %s: %v", action.Address, err)
 		}
-		return nil, err
 	}
 
-	var s map[string]int
-	json.Unmarshal(raw, &s)
-	return s, nil
-}
-
-func saveState(path string, s map[string]int) {
-	b, _ := json.MarshalIndent(s, "", "  ")
-	_ = os.WriteFile(path, b, 0644)
+	return nil
 }
 
-// ----------------------------------------------
-
-func getTimeout(count int, hours []int) string {
-	if count <= len(hours) {
-		return fmt.Sprintf("%02d:00:00", hours[count-1])
-	}
-	return "0"
-}
-
-// ----------------------------------------------
-
-func main() {
-
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: ./blocker attackers.csv")
+// printDiff fetches the current contents of every list actions touch and
+// prints what would change, without calling Block.
+func printDiff(ctx context.Context, b blocker.Blocker, cfg *config.Config, actions []engine.Action) error {
+	current := make(map[string]map[string]bool)
+	for _, list := range []string{cfg.ListTemp, cfg.ListPerm} {
+		addrs, err := b.ListAddresses(ctx, list)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", list, err)
+		}
+		set := make(map[string]bool, len(addrs))
+		for _, a := range addrs {
+			set[a] = true
+		}
+		current[list] = set
 	}
 
-	csvPath := os.Args[1]
-
-	// BASE DIR
-	baseDir := "/opt/htb_blocker"
-
-	// --- AUTO SETUP ---
-	ensureBaseDir(baseDir)
-	ensureConfig(baseDir)
-
-	cfg, err := loadConfig(filepath.Join(baseDir, "config.env"))
-	if err != nil {
-		log.Fatalf("Config load error: %v", err)
+	for _, action := range actions {
+		if current[action.List][action.Address] {
+			fmt.Printf("= %s already in %s\n", action.Address, action.List)
+			continue
+		}
+		fmt.Printf("+ %s -> %s (timeout=%s)\n", action.Address, action.List, action.Timeout)
 	}
+	return nil
+}
 
-	state, _ := loadState(cfg.StateFile)
-
-	ips, err := loadIPsFromCSV(csvPath)
+func runDaemon(configPath string, cfg *config.Config) error {
+	st, err := state.Open(cfg.StateFile)
 	if err != nil {
-		log.Fatalf("CSV error: %v", err)
+		return fmt.Errorf("state open error: %w", err)
 	}
 
-	client, err := routeros.Dial(cfg.MtHost, cfg.MtUser, cfg.MtPass)
+	b, err := dialBlocker(cfg)
 	if err != nil {
-		log.Fatalf("Mikrotik error: %v", err)
+		return fmt.Errorf("mikrotik error: %w", err)
 	}
-	defer client.Close()
-
-	for _, ip := range ips {
-
-		// --- 1) SANITIZE → فقط IP واقعی را نگه داریم ---
-		ip = sanitizeIP(ip)
-		if ip == "" {
-			log.Printf("⚠️ Skipping invalid IP (after sanitize)")
-			continue
-		}
 
-		// --- 2) Whitelist ---
-		if isWhitelisted(ip, cfg.Whitelist) {
-			log.Printf("⚪ %s → SKIP (whitelisted)", ip)
-			continue
-		}
+	d := daemon.New(cfg, buildScheduledFeeds(cfg), b, st)
 
-		// --- 3) State update ---
-		state[ip]++
-		timeout := getTimeout(state[ip], cfg.Escalation)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
-		// --- 4) Permanent block ---
-		if timeout == "0" {
-			log.Printf("🚫 %s → Permanent block", ip)
+	go watchForReload(ctx, configPath, d)
 
-			_, _ = client.RunArgs([]string{
-				"/ip/firewall/address-list/add",
-				"=list=" + cfg.ListPerm,
-				"=address=" + ip,
-				"=timeout=0",
-			})
+	return d.Run(ctx)
+}
 
-			continue
+// watchForReload reloads config.yaml and restarts feeds on SIGHUP,
+// without dropping the RouterOS session or in-memory state.
+func watchForReload(ctx context.Context, configPath string, d *daemon.Daemon) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				log.Printf("❌ reload failed, keeping previous config: %v", err)
+				continue
+			}
+			d.Reload(cfg, buildScheduledFeeds(cfg))
 		}
+	}
+}
 
-		// --- 5) Temporary (escalated) block ---
-		log.Printf("🛡️ %s → attempt %d → timeout %s", ip, state[ip], timeout)
-
-		_, err := client.RunArgs([]string{
-			"/ip/firewall/address-list/add",
-			"=list=" + cfg.ListTemp,
-			"=address=" + ip,
-			"=timeout=" + timeout,
-		})
+// runAuditExport dumps the state store's audit log to stdout in the
+// requested format, for forwarding to a SIEM.
+func runAuditExport(cfg *config.Config, format string) error {
+	st, err := state.Open(cfg.StateFile)
+	if err != nil {
+		return fmt.Errorf("state open error: %w", err)
+	}
+	defer st.Close()
 
+	switch format {
+	case "json":
+		return st.ExportAuditJSON(os.Stdout)
+	case "syslog":
+		w, err := syslog.New(syslog.LOG_INFO, "blocker")
 		if err != nil {
-			log.Printf("❌ Block failed for %s: %v", ip, err)
+			return fmt.Errorf("connect to syslog: %w", err)
 		}
+		defer w.Close()
+		return st.ExportAuditSyslog(w)
+	default:
+		return fmt.Errorf("unknown format %q (want json or syslog)", format)
+	}
+}
+
+// ----------------------------------------------
+
+func main() {
+	app := &cli.App{
+		Name:  "blocker",
+		Usage: "sync threat feeds into RouterOS address lists",
+		Commands: []*cli.Command{
+			{
+				Name:  "run",
+				Usage: "fetch every configured feed once and exit",
+				Flags: []cli.Flag{
+					configFlag,
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "print what would be added/escalated without mutating the router",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return fmt.Errorf("config load error: %w", err)
+					}
+					return runOnce(cfg, c.Bool("dry-run"))
+				},
+			},
+			{
+				Name:  "daemon",
+				Usage: "poll every configured feed on its own schedule until stopped",
+				Flags: []cli.Flag{configFlag},
+				Action: func(c *cli.Context) error {
+					path := c.String("config")
+					cfg, err := config.Load(path)
+					if err != nil {
+						return fmt.Errorf("config load error: %w", err)
+					}
+					return runDaemon(path, cfg)
+				},
+			},
+			{
+				Name:      "generate-config",
+				Usage:     "write a fully-commented default config.yaml",
+				ArgsUsage: "[path]",
+				Action: func(c *cli.Context) error {
+					path := c.Args().First()
+					if path == "" {
+						path = defaultConfigPath
+					}
+					if _, err := os.Stat(path); err == nil {
+						return fmt.Errorf("%s already exists; remove it first or pass a different path", path)
+					}
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						return err
+					}
+					if err := os.WriteFile(path, []byte(config.GenerateDefault()), 0644); err != nil {
+						return err
+					}
+					fmt.Printf("✅ wrote default config to %s\n", path)
+					return nil
+				},
+			},
+			{
+				Name:  "audit-export",
+				Usage: "export the state store's audit log (add/escalate/decay/expire events)",
+				Flags: []cli.Flag{
+					configFlag,
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "json",
+						Usage: "json or syslog",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return fmt.Errorf("config load error: %w", err)
+					}
+					return runAuditExport(cfg, c.String("format"))
+				},
+			},
+			{
+				Name:      "validate-config",
+				Usage:     "parse config.yaml and report errors",
+				ArgsUsage: "[path]",
+				Action: func(c *cli.Context) error {
+					path := c.Args().First()
+					if path == "" {
+						path = defaultConfigPath
+					}
+					if _, err := config.Load(path); err != nil {
+						return fmt.Errorf("%s: %w", path, err)
+					}
+					fmt.Printf("✅ %s is valid\n", path)
+					return nil
+				},
+			},
+		},
 	}
 
-	saveState(cfg.StateFile, state)
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,79 @@
+// Package netutil holds the address-validation and CIDR helpers shared by
+// the one-shot and daemon run paths.
+package netutil
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefix turns a raw indicator value into a netip.Prefix. A bare IPv4
+// or IPv6 address becomes a single-address prefix (/32 or /128); a CIDR
+// literal is masked to its network address (1.2.3.4/24 becomes
+// 1.2.3.0/24). CSV/quote noise is trimmed first.
+func ParsePrefix(raw string) (netip.Prefix, bool) {
+	raw = strings.Trim(raw, "\"")
+	if strings.Contains(raw, ",") {
+		raw = strings.Split(raw, ",")[0]
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return netip.Prefix{}, false
+	}
+
+	if strings.Contains(raw, "/") {
+		p, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		return p.Masked(), true
+	}
+
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+// IsHost reports whether p is a single address (/32 for IPv4, /128 for
+// IPv6) rather than a genuine range.
+func IsHost(p netip.Prefix) bool {
+	return p.Bits() == p.Addr().BitLen()
+}
+
+// ContainingPrefix returns the subnet addr belongs to when masked
+// to maskV4 bits (IPv4) or maskV6 bits (IPv6).
+func ContainingPrefix(addr netip.Addr, maskV4, maskV6 int) netip.Prefix {
+	bits := maskV4
+	if addr.Is6() && !addr.Is4In6() {
+		bits = maskV6
+	}
+	p, err := addr.Prefix(bits)
+	if err != nil {
+		return netip.PrefixFrom(addr, addr.BitLen())
+	}
+	return p
+}
+
+// ParseWhitelist parses the config's whitelist entries (bare IPs or
+// CIDRs) into prefixes, skipping anything unparseable.
+func ParseWhitelist(entries []string) []netip.Prefix {
+	out := make([]netip.Prefix, 0, len(entries))
+	for _, e := range entries {
+		if p, ok := ParsePrefix(strings.TrimSpace(e)); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// IsWhitelisted reports whether addr falls inside any whitelisted prefix.
+func IsWhitelisted(addr netip.Addr, wl []netip.Prefix) bool {
+	for _, p := range wl {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package netutil
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParsePrefix(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want string
+		ok   bool
+	}{
+		{name: "bare ipv4", raw: "1.2.3.4", want: "1.2.3.4/32", ok: true},
+		{name: "bare ipv6", raw: "2001:db8::1", want: "2001:db8::1/128", ok: true},
+		{name: "ipv4 cidr", raw: "1.2.3.0/24", want: "1.2.3.0/24", ok: true},
+		{name: "ipv6 cidr", raw: "2001:db8::/32", want: "2001:db8::/32", ok: true},
+		{name: "cidr gets masked", raw: "1.2.3.4/24", want: "1.2.3.0/24", ok: true},
+		{name: "quoted csv noise", raw: "\"1.2.3.4\"", want: "1.2.3.4/32", ok: true},
+		{name: "padded", raw: "  1.2.3.4  ", want: "1.2.3.4/32", ok: true},
+		{name: "empty", raw: "", ok: false},
+		{name: "garbage", raw: "not-an-ip", ok: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := ParsePrefix(tc.raw)
+			if ok != tc.ok {
+				t.Fatalf("ParsePrefix(%q) ok = %v, want %v", tc.raw, ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			if got := p.String(); got != tc.want {
+				t.Fatalf("ParsePrefix(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsHost(t *testing.T) {
+	host := netip.MustParsePrefix("1.2.3.4/32")
+	if !IsHost(host) {
+		t.Fatalf("IsHost(%v) = false, want true", host)
+	}
+	rng := netip.MustParsePrefix("1.2.3.0/24")
+	if IsHost(rng) {
+		t.Fatalf("IsHost(%v) = true, want false", rng)
+	}
+}
+
+func TestContainingPrefix(t *testing.T) {
+	v4 := netip.MustParseAddr("1.2.3.4")
+	if got, want := ContainingPrefix(v4, 24, 64).String(), "1.2.3.0/24"; got != want {
+		t.Fatalf("ContainingPrefix(v4) = %q, want %q", got, want)
+	}
+
+	v6 := netip.MustParseAddr("2001:db8::1")
+	if got, want := ContainingPrefix(v6, 24, 64).String(), "2001:db8::/64"; got != want {
+		t.Fatalf("ContainingPrefix(v6) = %q, want %q", got, want)
+	}
+}
+
+func TestIsWhitelisted(t *testing.T) {
+	wl := ParseWhitelist([]string{"8.8.8.8", "192.168.1.0/24"})
+
+	if !IsWhitelisted(netip.MustParseAddr("8.8.8.8"), wl) {
+		t.Fatal("expected 8.8.8.8 to be whitelisted")
+	}
+	if !IsWhitelisted(netip.MustParseAddr("192.168.1.55"), wl) {
+		t.Fatal("expected 192.168.1.55 to be whitelisted via CIDR")
+	}
+	if IsWhitelisted(netip.MustParseAddr("1.2.3.4"), wl) {
+		t.Fatal("expected 1.2.3.4 not to be whitelisted")
+	}
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// ExportAuditJSON writes every audit event to w as newline-delimited JSON,
+// oldest first, suitable for ingestion by a SIEM's file/log collector.
+func (s *Store) ExportAuditJSON(w io.Writer) error {
+	events, err := s.AuditEvents()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportAuditSyslog writes every audit event to w as an individual syslog
+// message, oldest first.
+func (s *Store) ExportAuditSyslog(w *syslog.Writer) error {
+	events, err := s.AuditEvents()
+	if err != nil {
+		return err
+	}
+	for _, ev := range events {
+		msg := fmt.Sprintf("blocker audit: action=%s key=%s list=%s timeout=%s source=%s time=%s",
+			ev.Action, ev.Key, ev.List, ev.Timeout, ev.Source, ev.Time.Format("2006-01-02T15:04:05Z07:00"))
+		if err := w.Info(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Reap decays or expires every host and subnet record not seen within
+// maxAge: its count is decremented by one and LastSeen reset (an attacker
+// who stopped is gradually de-escalated), or, once count reaches zero, the
+// record is deleted outright. Both transitions are written to the audit
+// log. It returns how many records were decayed and how many were
+// expired.
+func (s *Store) Reap(maxAge time.Duration) (decayed, expired int, err error) {
+	now := time.Now()
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{hostsBucket, subnetsBucket} {
+			d, e, err := reapBucket(tx, bucket, now, maxAge)
+			if err != nil {
+				return err
+			}
+			decayed += d
+			expired += e
+		}
+		return nil
+	})
+	return decayed, expired, err
+}
+
+func reapBucket(tx *bbolt.Tx, bucket []byte, now time.Time, maxAge time.Duration) (decayed, expired int, err error) {
+	b := tx.Bucket(bucket)
+
+	type stale struct {
+		key []byte
+		rec Record
+	}
+	var candidates []stale
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var rec Record
+		if err := json.Unmarshal(v, &rec); err != nil {
+			continue
+		}
+		if now.Sub(rec.LastSeen) < maxAge {
+			continue
+		}
+		candidates = append(candidates, stale{key: append([]byte(nil), k...), rec: rec})
+	}
+
+	for _, st := range candidates {
+		st.rec.Count--
+		if st.rec.Count <= 0 {
+			if err := b.Delete(st.key); err != nil {
+				return decayed, expired, err
+			}
+			if err := writeAudit(tx, AuditEvent{Time: now, Action: "expire", Key: string(st.key), Source: st.rec.Source}); err != nil {
+				return decayed, expired, err
+			}
+			expired++
+			continue
+		}
+
+		st.rec.LastSeen = now
+		data, err := json.Marshal(st.rec)
+		if err != nil {
+			return decayed, expired, err
+		}
+		if err := b.Put(st.key, data); err != nil {
+			return decayed, expired, err
+		}
+		if err := writeAudit(tx, AuditEvent{Time: now, Action: "decay", Key: string(st.key), Source: st.rec.Source}); err != nil {
+			return decayed, expired, err
+		}
+		decayed++
+	}
+
+	return decayed, expired, nil
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReap_DecaysStaleRecord(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer st.Close()
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := st.PutHost("1.2.3.4/32", Record{Count: 3, FirstSeen: old, LastSeen: old}); err != nil {
+		t.Fatalf("PutHost: %v", err)
+	}
+
+	decayed, expired, err := st.Reap(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+	if decayed != 1 || expired != 0 {
+		t.Fatalf("Reap() = decayed=%d expired=%d, want decayed=1 expired=0", decayed, expired)
+	}
+
+	rec, err := st.GetHost("1.2.3.4/32")
+	if err != nil {
+		t.Fatalf("GetHost: %v", err)
+	}
+	if rec.Count != 2 {
+		t.Fatalf("after decay Count = %d, want 2", rec.Count)
+	}
+	if rec.LastSeen.Before(old) || !rec.LastSeen.After(old) {
+		t.Fatalf("decay did not reset LastSeen: got %v, want after %v", rec.LastSeen, old)
+	}
+
+	events, err := st.AuditEvents()
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != "decay" {
+		t.Fatalf("audit events = %+v, want one decay event", events)
+	}
+}
+
+func TestReap_ExpiresZeroedRecord(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer st.Close()
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := st.PutHost("1.2.3.4/32", Record{Count: 1, FirstSeen: old, LastSeen: old}); err != nil {
+		t.Fatalf("PutHost: %v", err)
+	}
+
+	decayed, expired, err := st.Reap(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+	if decayed != 0 || expired != 1 {
+		t.Fatalf("Reap() = decayed=%d expired=%d, want decayed=0 expired=1", decayed, expired)
+	}
+
+	rec, err := st.GetHost("1.2.3.4/32")
+	if err != nil {
+		t.Fatalf("GetHost: %v", err)
+	}
+	if rec.Count != 0 || !rec.FirstSeen.IsZero() {
+		t.Fatalf("expired record should be gone, got %+v", rec)
+	}
+
+	events, err := st.AuditEvents()
+	if err != nil {
+		t.Fatalf("AuditEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].Action != "expire" {
+		t.Fatalf("audit events = %+v, want one expire event", events)
+	}
+}
+
+func TestReap_SkipsFreshRecord(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer st.Close()
+
+	now := time.Now()
+	if err := st.PutHost("1.2.3.4/32", Record{Count: 2, FirstSeen: now, LastSeen: now}); err != nil {
+		t.Fatalf("PutHost: %v", err)
+	}
+
+	decayed, expired, err := st.Reap(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Reap: %v", err)
+	}
+	if decayed != 0 || expired != 0 {
+		t.Fatalf("Reap() on a fresh record = decayed=%d expired=%d, want 0/0", decayed, expired)
+	}
+}
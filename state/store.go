@@ -0,0 +1,192 @@
+// Package state persists per-address and per-subnet escalation records in
+// an embedded bbolt database, alongside an append-only audit log of every
+// add/escalate/expire/decay event for export to a SIEM.
+package state
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	hostsBucket   = []byte("hosts")
+	subnetsBucket = []byte("subnets")
+	auditBucket   = []byte("audit")
+)
+
+// Record tracks one host or subnet's escalation progress. FirstSeen and
+// LastSeen bound its lifetime for the reaper; ExpiresAt mirrors the
+// timeout last pushed to RouterOS so the two stay in sync; Aggregated is
+// only meaningful on subnet records, marking that the subnet has already
+// been escalated as a single range.
+type Record struct {
+	Count      int       `json:"count"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	Source     string    `json:"source"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+	Aggregated bool      `json:"aggregated,omitempty"`
+}
+
+// AuditEvent is one append-only entry describing a state change, suitable
+// for export to a SIEM as JSON or syslog.
+type AuditEvent struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"` // add, escalate, decay, expire
+	Key     string    `json:"key"`
+	List    string    `json:"list,omitempty"`
+	Timeout string    `json:"timeout,omitempty"`
+	Source  string    `json:"source,omitempty"`
+}
+
+// Store is a bbolt-backed replacement for the old JSON-file State: every
+// Put/Delete commits immediately, so there is no separate Save step.
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open opens (creating if necessary) the bbolt database at path and
+// ensures its buckets exist.
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, b := range [][]byte{hostsBucket, subnetsBucket, auditBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// GetHost returns the record for hostKey, or a zero Record if it has never
+// been seen.
+func (s *Store) GetHost(hostKey string) (Record, error) {
+	return s.get(hostsBucket, hostKey)
+}
+
+// PutHost writes the record for hostKey.
+func (s *Store) PutHost(hostKey string, rec Record) error {
+	return s.put(hostsBucket, hostKey, rec)
+}
+
+// GetSubnet returns the record for subnetKey, or a zero Record if it has
+// never been seen.
+func (s *Store) GetSubnet(subnetKey string) (Record, error) {
+	return s.get(subnetsBucket, subnetKey)
+}
+
+// PutSubnet writes the record for subnetKey.
+func (s *Store) PutSubnet(subnetKey string, rec Record) error {
+	return s.put(subnetsBucket, subnetKey, rec)
+}
+
+// ForgetHostsIn deletes every host record contained in subnet, called once
+// the subnet has been aggregated into a single range entry.
+func (s *Store) ForgetHostsIn(subnet netip.Prefix) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(hostsBucket)
+		var stale [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			p, err := netip.ParsePrefix(string(k))
+			if err == nil && subnet.Contains(p.Addr()) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Size returns the number of host and subnet records currently tracked.
+func (s *Store) Size() (int, error) {
+	n := 0
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		n += tx.Bucket(hostsBucket).Stats().KeyN
+		n += tx.Bucket(subnetsBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// Audit appends ev to the audit log.
+func (s *Store) Audit(ev AuditEvent) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return writeAudit(tx, ev)
+	})
+}
+
+// AuditEvents returns every recorded audit event, oldest first.
+func (s *Store) AuditEvents() ([]AuditEvent, error) {
+	var events []AuditEvent
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(auditBucket).ForEach(func(_, v []byte) error {
+			var ev AuditEvent
+			if err := json.Unmarshal(v, &ev); err != nil {
+				return err
+			}
+			events = append(events, ev)
+			return nil
+		})
+	})
+	return events, err
+}
+
+func (s *Store) get(bucket []byte, key string) (Record, error) {
+	var rec Record
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(bucket).Get([]byte(key))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &rec)
+	})
+	return rec, err
+}
+
+func (s *Store) put(bucket []byte, key string, rec Record) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+func writeAudit(tx *bbolt.Tx, ev AuditEvent) error {
+	b := tx.Bucket(auditBucket)
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}